@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/worker"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"lukechampine.com/frand"
+)
+
+// parseSize parses a human size like "1M", "64M", or "1G" into bytes.
+// A bare number is interpreted as bytes.
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+
+	mul := int64(1)
+	switch s[len(s)-1] {
+	case 'k', 'K':
+		mul, s = 1<<10, s[:len(s)-1]
+	case 'm', 'M':
+		mul, s = 1<<20, s[:len(s)-1]
+	case 'g', 'G':
+		mul, s = 1<<30, s[:len(s)-1]
+	case 't', 'T':
+		mul, s = 1<<40, s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+	return n * mul, nil
+}
+
+// opStats collects latencies for one operation kind (upload, download,
+// delete) across all benchmark threads.
+type opStats struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	errors    uint64
+	bytes     uint64
+}
+
+func (s *opStats) record(d time.Duration, n int64, err error) {
+	if err != nil {
+		atomic.AddUint64(&s.errors, 1)
+		return
+	}
+	s.mu.Lock()
+	s.latencies = append(s.latencies, d)
+	s.mu.Unlock()
+	atomic.AddUint64(&s.bytes, uint64(n))
+}
+
+// count returns the number of successful operations recorded so far.
+func (s *opStats) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.latencies)
+}
+
+type opSummary struct {
+	Ops    int           `json:"ops"`
+	Errors uint64        `json:"errors"`
+	Bytes  uint64        `json:"bytes"`
+	OpsSec float64       `json:"opsSec"`
+	Bps    string        `json:"bps"`
+	Min    time.Duration `json:"minLatency"`
+	Avg    time.Duration `json:"avgLatency"`
+	P50    time.Duration `json:"p50Latency"`
+	P95    time.Duration `json:"p95Latency"`
+	P99    time.Duration `json:"p99Latency"`
+	Max    time.Duration `json:"maxLatency"`
+}
+
+func (s *opStats) summarize(elapsed time.Duration) opSummary {
+	s.mu.Lock()
+	latencies := append([]time.Duration(nil), s.latencies...)
+	s.mu.Unlock()
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	summary := opSummary{
+		Ops:    len(latencies),
+		Errors: atomic.LoadUint64(&s.errors),
+		Bytes:  atomic.LoadUint64(&s.bytes),
+		OpsSec: float64(len(latencies)) / elapsed.Seconds(),
+		Bps:    formatBpsString(atomic.LoadUint64(&s.bytes), elapsed),
+	}
+	if len(latencies) == 0 {
+		return summary
+	}
+
+	percentile := func(p float64) time.Duration {
+		idx := int(p * float64(len(latencies)-1))
+		return latencies[idx]
+	}
+
+	var sum time.Duration
+	for _, d := range latencies {
+		sum += d
+	}
+	summary.Min = latencies[0]
+	summary.Max = latencies[len(latencies)-1]
+	summary.Avg = sum / time.Duration(len(latencies))
+	summary.P50 = percentile(0.50)
+	summary.P95 = percentile(0.95)
+	summary.P99 = percentile(0.99)
+	return summary
+}
+
+type benchmarkReport struct {
+	ObjectSize int64         `json:"objectSize"`
+	Threads    int           `json:"threads"`
+	Duration   time.Duration `json:"duration"`
+	Upload     opSummary     `json:"upload"`
+	Download   opSummary     `json:"download"`
+	Delete     opSummary     `json:"delete"`
+}
+
+// runBenchmark drives the worker's upload/download/delete endpoints with
+// synthetic payloads for the configured duration and reports latency and
+// throughput statistics.
+func runBenchmark(log *zap.Logger, workerClient *worker.Client) error {
+	size, err := parseSize(benchSize)
+	if err != nil {
+		return fmt.Errorf("invalid -bench.size: %w", err)
+	}
+	payload := frand.Bytes(int(size))
+
+	var uploads, downloads, deletes opStats
+	var keyCounter uint64
+
+	s := rate.Sometimes{Interval: 10 * time.Second}
+	start := time.Now()
+	deadline := start.Add(benchDuration)
+
+	var wg sync.WaitGroup
+	for i := 0; i < benchThreads; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				key := fmt.Sprintf("bench/%d/%d", worker, atomic.AddUint64(&keyCounter, 1))
+
+				uploadStart := time.Now()
+				_, err := workerClient.UploadObject(context.Background(), strings.NewReader(string(payload)), benchBucket, key, api.UploadObjectOptions{})
+				uploads.record(time.Since(uploadStart), size, err)
+
+				downloadStart := time.Now()
+				resp, err := workerClient.GetObject(context.Background(), benchBucket, key, api.DownloadObjectOptions{})
+				if err == nil {
+					_, err = io.Copy(io.Discard, resp.Content)
+					resp.Content.Close()
+				}
+				downloads.record(time.Since(downloadStart), size, err)
+
+				deleteStart := time.Now()
+				err = workerClient.DeleteObject(context.Background(), benchBucket, key, api.DeleteObjectOptions{})
+				deletes.record(time.Since(deleteStart), 0, err)
+
+				s.Do(func() {
+					log.Info("benchmark progress",
+						zap.Int("uploads", uploads.count()),
+						zap.Int("downloads", downloads.count()),
+						zap.Int("deletes", deletes.count()),
+						zap.Duration("elapsed", time.Since(start)))
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	report := benchmarkReport{
+		ObjectSize: size,
+		Threads:    benchThreads,
+		Duration:   elapsed,
+		Upload:     uploads.summarize(elapsed),
+		Download:   downloads.summarize(elapsed),
+		Delete:     deletes.summarize(elapsed),
+	}
+
+	log.Info("benchmark complete",
+		zap.Int("uploadOps", report.Upload.Ops), zap.Uint64("uploadErrors", report.Upload.Errors), zap.String("uploadBps", report.Upload.Bps),
+		zap.Duration("uploadP50", report.Upload.P50), zap.Duration("uploadP95", report.Upload.P95), zap.Duration("uploadP99", report.Upload.P99),
+		zap.Int("downloadOps", report.Download.Ops), zap.Uint64("downloadErrors", report.Download.Errors), zap.String("downloadBps", report.Download.Bps),
+		zap.Int("deleteOps", report.Delete.Ops), zap.Uint64("deleteErrors", report.Delete.Errors))
+
+	f, err := os.Create(benchOutput)
+	if err != nil {
+		return fmt.Errorf("failed to create benchmark output: %w", err)
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("failed to write benchmark output: %w", err)
+	}
+	return nil
+}
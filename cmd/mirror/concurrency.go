@@ -0,0 +1,122 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// adaptiveLimiter is a resizable counting semaphore. Unlike a plain buffered
+// channel, its limit can be raised or lowered while goroutines are already
+// waiting on it, which is what lets the AIMD controller ramp concurrency up
+// or down at runtime.
+type adaptiveLimiter struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active int
+}
+
+func newAdaptiveLimiter(initial int) *adaptiveLimiter {
+	l := &adaptiveLimiter{limit: initial}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+func (l *adaptiveLimiter) acquire() {
+	l.mu.Lock()
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+	l.mu.Unlock()
+}
+
+func (l *adaptiveLimiter) release() {
+	l.mu.Lock()
+	l.active--
+	l.cond.Signal()
+	l.mu.Unlock()
+}
+
+func (l *adaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+func (l *adaptiveLimiter) setLimit(n int) {
+	l.mu.Lock()
+	l.limit = n
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
+
+// uploadStats accumulates upload outcomes between AIMD evaluations.
+type uploadStats struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	failures  int
+}
+
+func (s *uploadStats) record(d time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if failed {
+		s.failures++
+		return
+	}
+	s.latencies = append(s.latencies, d)
+}
+
+// snapshotAndReset returns the p95 latency and failure ratio observed since
+// the last call, then clears the accumulated samples.
+func (s *uploadStats) snapshotAndReset() (p95 time.Duration, failureRatio float64) {
+	s.mu.Lock()
+	latencies := s.latencies
+	failures := s.failures
+	s.latencies = nil
+	s.failures = 0
+	s.mu.Unlock()
+
+	total := len(latencies) + failures
+	if total == 0 {
+		return 0, 0
+	}
+	failureRatio = float64(failures) / float64(total)
+	if len(latencies) == 0 {
+		return 0, failureRatio
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	p95 = latencies[int(0.95*float64(len(latencies)-1))]
+	return p95, failureRatio
+}
+
+// runAIMDController periodically ramps limiter's concurrency up toward max
+// while uploads stay fast and reliable, and backs off toward min as soon as
+// latency or failures climb, so the mirror doesn't overwhelm the worker.
+func runAIMDController(stats *uploadStats, limiter *adaptiveLimiter, min, max int, targetP95 time.Duration, failureRatioThreshold float64) {
+	t := time.NewTicker(30 * time.Second)
+	defer t.Stop()
+	for range t.C {
+		p95, failureRatio := stats.snapshotAndReset()
+		current := limiter.Limit()
+
+		var next int
+		if failureRatio > failureRatioThreshold || p95 > targetP95 {
+			next = current / 2 // multiplicative decrease
+			if next < min {
+				next = min
+			}
+		} else {
+			next = current + 1 // additive increase
+			if next > max {
+				next = max
+			}
+		}
+		if next != current {
+			limiter.setLimit(next)
+		}
+	}
+}
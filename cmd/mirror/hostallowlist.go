@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/siacentral/apisdkgo/sia"
+	"go.etcd.io/bbolt"
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/bus"
+	"go.uber.org/zap"
+)
+
+// emaAlpha weights how quickly a host's score reacts to a fresh observation
+// versus its prior history.
+const emaAlpha = 0.3
+
+var hostsBucket = []byte("hosts")
+
+// hostScore is the scored, decayed view of a single host that the allowlist
+// maintainer accumulates across cycles.
+type hostScore struct {
+	PublicKey      types.PublicKey
+	UploadEMA      float64
+	DownloadEMA    float64
+	SuccessRatio   float64
+	PriceGB        float64
+	ContractActive bool
+	LastSeen       time.Time
+	CooldownUntil  time.Time
+}
+
+func (s hostScore) onCooldown() bool {
+	return time.Now().Before(s.CooldownUntil)
+}
+
+// hostTable is a persistent, scored table of known hosts, backed by a bolt
+// database so scores survive restarts.
+type hostTable struct {
+	db *bbolt.DB
+}
+
+func openHostTable(path string) (*hostTable, error) {
+	db, err := bbolt.Open(path, 0o644, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open host table: %w", err)
+	} else if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(hostsBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to init host table: %w", err)
+	}
+	return &hostTable{db: db}, nil
+}
+
+func (t *hostTable) get(pub types.PublicKey) (score hostScore, ok bool, err error) {
+	err = t.db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket(hostsBucket).Get(pub[:])
+		if v == nil {
+			return nil
+		}
+		ok = true
+		return gob.NewDecoder(bytes.NewReader(v)).Decode(&score)
+	})
+	return
+}
+
+func (t *hostTable) put(score hostScore) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(score); err != nil {
+		return fmt.Errorf("failed to encode host score: %w", err)
+	}
+	pub := score.PublicKey
+	return t.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(hostsBucket).Put(pub[:], buf.Bytes())
+	})
+}
+
+// all returns every scored host, for the inspection endpoint.
+func (t *hostTable) all() (scores []hostScore, err error) {
+	err = t.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(hostsBucket).ForEach(func(_, v []byte) error {
+			var score hostScore
+			if err := gob.NewDecoder(bytes.NewReader(v)).Decode(&score); err != nil {
+				return err
+			}
+			scores = append(scores, score)
+			return nil
+		})
+	})
+	return
+}
+
+func (t *hostTable) Close() error {
+	return t.db.Close()
+}
+
+// serveHostTable exposes the current scoring table on addr so operators can
+// inspect why a host is or isn't allowlisted.
+func serveHostTable(log *zap.Logger, addr string, table *hostTable) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hosts", func(w http.ResponseWriter, r *http.Request) {
+		scores, err := table.all()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(scores)
+	})
+	log.Info("serving host allowlist table", zap.String("addr", addr))
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Error("host allowlist server stopped", zap.Error(err))
+	}
+}
+
+// updateHostAllowlist fetches up to five pages of 100 candidate hosts from
+// SiaCentral per cycle, updates their EMA-decayed scores, applies re-entry cooldowns to
+// hosts that drop out, and pushes only the additions/removals delta to the
+// bus rather than replacing the allowlist wholesale.
+func updateHostAllowlist(ctx context.Context, table *hostTable, cooldown time.Duration) (added int, removed int, _ error) {
+	sc := sia.NewClient()
+	b := bus.NewClient(busAddr, busPass)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	allowlist, err := b.HostAllowlist(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get host allowlist: %w", err)
+	}
+	currentlyAllowed := make(map[types.PublicKey]bool, len(allowlist))
+	for _, host := range allowlist {
+		currentlyAllowed[host] = true
+	}
+
+	activeContracts, err := b.Contracts(ctx, api.ContractsOpts{ContractSet: contractSet})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get active contracts: %w", err)
+	}
+	activeHosts := make(map[types.PublicKey]bool, len(activeContracts))
+	for _, c := range activeContracts {
+		activeHosts[c.HostKey] = true
+	}
+
+	seen := make(map[types.PublicKey]bool)
+	pagesFetched := 0
+	for i := 0; i < 5; i++ {
+		select {
+		case <-ctx.Done():
+			return 0, 0, ctx.Err()
+		default:
+		}
+
+		hosts, err := sc.GetActiveHosts(i, 100,
+			sia.HostFilterBenchmarked(true),
+			sia.HostFilterSort(sia.HostSortDownloadSpeed, true))
+		if err != nil {
+			break
+		}
+		pagesFetched++
+
+		for _, host := range hosts {
+			var pub types.PublicKey
+			if err := pub.UnmarshalText([]byte(host.PublicKey)); err != nil {
+				return 0, 0, fmt.Errorf("failed to unmarshal public key: %w", err)
+			}
+			seen[pub] = true
+
+			score, _, err := table.get(pub)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to load score for %v: %w", pub, err)
+			}
+			score.PublicKey = pub
+			score.UploadEMA = emaAlpha*host.Benchmark.UploadSpeed + (1-emaAlpha)*score.UploadEMA
+			score.DownloadEMA = emaAlpha*host.Benchmark.DownloadSpeed + (1-emaAlpha)*score.DownloadEMA
+			score.PriceGB = emaAlpha*host.Settings.StoragePrice + (1-emaAlpha)*score.PriceGB
+			score.SuccessRatio = emaAlpha*1 + (1-emaAlpha)*score.SuccessRatio
+			score.ContractActive = activeHosts[pub]
+			score.LastSeen = time.Now()
+			if err := table.put(score); err != nil {
+				return 0, 0, fmt.Errorf("failed to store score for %v: %w", pub, err)
+			}
+		}
+	}
+
+	if pagesFetched == 0 {
+		// SiaCentral was unreachable/rate-limited this cycle; treating an
+		// empty candidate set as "nothing is good anymore" would remove the
+		// entire allowlist, so skip the cycle instead.
+		return 0, 0, nil
+	}
+
+	var goodHosts, badHosts []types.PublicKey
+	for pub := range seen {
+		score, _, err := table.get(pub)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to load score for %v: %w", pub, err)
+		}
+		if !currentlyAllowed[pub] && !score.onCooldown() {
+			goodHosts = append(goodHosts, pub)
+		}
+	}
+	for pub := range currentlyAllowed {
+		if seen[pub] {
+			continue
+		}
+		badHosts = append(badHosts, pub)
+
+		score, _, err := table.get(pub)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to load score for %v: %w", pub, err)
+		}
+		score.PublicKey = pub
+		score.SuccessRatio = emaAlpha*0 + (1-emaAlpha)*score.SuccessRatio
+		score.CooldownUntil = time.Now().Add(cooldown)
+		if err := table.put(score); err != nil {
+			return 0, 0, fmt.Errorf("failed to store score for %v: %w", pub, err)
+		}
+	}
+
+	if len(goodHosts) == 0 && len(badHosts) == 0 {
+		return 0, 0, nil
+	} else if err := b.UpdateHostAllowlist(ctx, goodHosts, badHosts, false); err != nil {
+		return 0, 0, fmt.Errorf("failed to update host allowlist: %w", err)
+	}
+	return len(goodHosts), len(badHosts), nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var ledgerBucket = []byte("objects")
+
+// ledgerEntry records everything we need to know about an object we've
+// already mirrored, so subsequent runs can skip it and the verify worker can
+// sample it later.
+type ledgerEntry struct {
+	ETag       string
+	Size       int64
+	Checksum   [32]byte
+	UploadedAt time.Time
+}
+
+// ledger is a persistent, resumable record of objects that have been
+// mirrored into renterd, keyed by "bucket/key".
+type ledger struct {
+	db *bbolt.DB
+
+	cursor []byte // first key not yet returned by sample, so repeated calls walk the table
+}
+
+// openLedger opens (creating if necessary) the ledger database at path.
+func openLedger(path string) (*ledger, error) {
+	db, err := bbolt.Open(path, 0o644, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger: %w", err)
+	} else if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(ledgerBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to init ledger: %w", err)
+	}
+	return &ledger{db: db}, nil
+}
+
+func ledgerKey(bucket, key string) []byte {
+	return []byte(bucket + "/" + key)
+}
+
+// get returns the recorded entry for bucket/key, if any.
+func (l *ledger) get(bucket, key string) (entry ledgerEntry, ok bool, err error) {
+	err = l.db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket(ledgerBucket).Get(ledgerKey(bucket, key))
+		if v == nil {
+			return nil
+		}
+		ok = true
+		return gob.NewDecoder(bytes.NewReader(v)).Decode(&entry)
+	})
+	return
+}
+
+// put records that bucket/key was successfully mirrored.
+func (l *ledger) put(bucket, key string, entry ledgerEntry) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entry); err != nil {
+		return fmt.Errorf("failed to encode ledger entry: %w", err)
+	}
+	return l.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(ledgerBucket).Put(ledgerKey(bucket, key), buf.Bytes())
+	})
+}
+
+// sample returns up to n arbitrary "bucket/key", entry pairs for the verify
+// worker to spot-check. Bolt iterates keys in sorted order, so the cursor is
+// advanced from a rotating start position instead of always returning the
+// same prefix.
+func (l *ledger) sample(n int) (keys []string, entries []ledgerEntry, err error) {
+	err = l.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(ledgerBucket).Cursor()
+		k, v := c.Seek(l.cursor)
+		if k == nil {
+			k, v = c.First()
+		}
+		for ; k != nil && len(keys) < n; k, v = c.Next() {
+			var entry ledgerEntry
+			if err := gob.NewDecoder(bytes.NewReader(v)).Decode(&entry); err != nil {
+				return fmt.Errorf("failed to decode ledger entry %q: %w", k, err)
+			}
+			keys = append(keys, string(k))
+			entries = append(entries, entry)
+		}
+		if k != nil {
+			l.cursor = append([]byte(nil), k...)
+		} else {
+			l.cursor = nil
+		}
+		return nil
+	})
+	return
+}
+
+func (l *ledger) Close() error {
+	return l.db.Close()
+}
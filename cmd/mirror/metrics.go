@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+var (
+	metricsRegistry = prometheus.NewRegistry()
+
+	objectsUploadedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "mirror_objects_uploaded_total",
+		Help: "Total number of objects successfully mirrored into renterd.",
+	})
+	bytesUploadedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mirror_bytes_uploaded_total",
+		Help: "Total bytes mirrored into renterd, by kind.",
+	}, []string{"kind"}) // kind: logical|redundant
+	uploadFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mirror_upload_failures_total",
+		Help: "Total number of failed upload attempts, by attempt number.",
+	}, []string{"attempt"})
+	uploadDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mirror_upload_duration_seconds",
+		Help:    "Duration of successful object uploads, bucketed by object-size class.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"sizeClass"})
+	uploadBps = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mirror_upload_bps",
+		Help:    "Observed upload throughput in bits per second, bucketed by object-size class.",
+		Buckets: prometheus.ExponentialBuckets(1<<20, 4, 10), // 1 Mbps .. ~256 Gbps
+	}, []string{"sizeClass"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(objectsUploadedTotal, bytesUploadedTotal, uploadFailuresTotal, uploadDurationSeconds, uploadBps)
+}
+
+// attemptLabel caps the "attempt" label on uploadFailuresTotal so a single
+// persistently-failing object (retried forever with capped backoff) can't
+// grow the CounterVec's cardinality without bound.
+func attemptLabel(attempt int) string {
+	const max = 3
+	if attempt >= max {
+		return fmt.Sprintf("%d+", max)
+	}
+	return strconv.Itoa(attempt)
+}
+
+// sizeClass buckets an object size into a coarse label for the upload-bps
+// histogram, so a mix of tiny and huge objects doesn't wash out either end.
+func sizeClass(size int64) string {
+	switch {
+	case size < 1<<20:
+		return "<1MiB"
+	case size < 64<<20:
+		return "1-64MiB"
+	case size < 1<<30:
+		return "64MiB-1GiB"
+	default:
+		return ">=1GiB"
+	}
+}
+
+// serveMetrics exposes the mirror's Prometheus registry on addr until the
+// process exits.
+func serveMetrics(log *zap.Logger, addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+	log.Info("serving metrics", zap.String("addr", addr))
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Error("metrics server stopped", zap.Error(err))
+	}
+}
+
+var requestID uint64
+
+// loggingRoundTripper wraps an http.RoundTripper, emitting a structured zap
+// log line for every upstream request so long-running mirrors are
+// observable beyond the periodic progress summaries.
+type loggingRoundTripper struct {
+	next http.RoundTripper
+	log  *zap.Logger
+}
+
+func (rt *loggingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := atomic.AddUint64(&requestID, 1)
+	start := time.Now()
+	resp, err := rt.next.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	fields := []zap.Field{
+		zap.String("requestID", fmt.Sprintf("%d", id)),
+		zap.String("method", req.Method),
+		zap.String("path", req.URL.Path),
+		zap.Duration("upstreamDuration", elapsed),
+		zap.Int64("requestBytes", req.ContentLength),
+	}
+	if err != nil {
+		rt.log.Error("upstream request failed", append(fields, zap.Error(err))...)
+		return resp, err
+	}
+	fields = append(fields, zap.Int("status", resp.StatusCode), zap.Int64("responseBytes", resp.ContentLength))
+	rt.log.Info("upstream request", fields...)
+	return resp, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimitedReader throttles reads from r to the rate allowed by limiter,
+// so a mirror can be capped to a configured bandwidth budget.
+type rateLimitedReader struct {
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+// newRateLimitedReader wraps r with limiter. A nil limiter disables
+// throttling entirely, so callers can pass it through unconditionally.
+func newRateLimitedReader(r io.Reader, limiter *rate.Limiter) io.Reader {
+	if limiter == nil {
+		return r
+	}
+	return &rateLimitedReader{r: r, limiter: limiter}
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n <= 0 {
+		return n, err
+	}
+
+	burst := r.limiter.Burst()
+	for remaining := n; remaining > 0; {
+		chunk := remaining
+		if chunk > burst {
+			chunk = burst
+		}
+		if werr := r.limiter.WaitN(context.Background(), chunk); werr != nil {
+			return n, werr
+		}
+		remaining -= chunk
+	}
+	return n, err
+}